@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 
@@ -18,15 +19,121 @@ import (
 	kanaryv1alpha1 "github.com/amadeusitgroup/kanary/pkg/apis/kanary/v1alpha1"
 )
 
+// NonRetriableError wraps an error that the controller should not keep retrying.
+type NonRetriableError struct {
+	err error
+}
+
+// NewNonRetriableError returns err wrapped as a NonRetriableError.
+func NewNonRetriableError(err error) error {
+	return &NonRetriableError{err: err}
+}
+
+// Error implements the error interface.
+func (e *NonRetriableError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *NonRetriableError) Unwrap() error {
+	return e.err
+}
+
+// IsNonRetriableError returns true if err is a NonRetriableError.
+func IsNonRetriableError(err error) bool {
+	_, ok := err.(*NonRetriableError)
+	return ok
+}
+
 // UpdateKanaryDeploymentStatusForFailure used to update the KanaryDeployment.Status if it has changed.
+// When err is non-retriable, the Failed condition is also set, result is reset to
+// reconcile.Result{} and err is swallowed (it has already been recorded on the status) so
+// the controller-runtime reconciler doesn't requeue a permanent failure: a non-nil error
+// triggers rate-limited requeuing regardless of Result. While kd is paused,
+// UpdateKanaryDeploymentStatus keeps the stored status, so the Errored and Failed
+// conditions set here are not written and the swallowed err is not recorded anywhere.
 func UpdateKanaryDeploymentStatusForFailure(kclient client.StatusWriter, reqLogger logr.Logger, kd *kanaryv1alpha1.KanaryDeployment, now metav1.Time, result reconcile.Result, err error) (reconcile.Result, error) {
 	newStatus := kd.Status.DeepCopy()
-	UpdateKanaryDeploymentStatusConditionsFailure(newStatus, now, err)
+	if !UpdateKanaryDeploymentStatusConditionsFailure(newStatus, now, err) {
+		result = reconcile.Result{}
+		err = nil
+	}
 	return UpdateKanaryDeploymentStatus(kclient, reqLogger, kd, newStatus, result, err)
 }
 
+// initializationCompleteReason is the condition Reason used once initialization has finished.
+const initializationCompleteReason = "InitializationComplete"
+
+// IsKanaryDeploymentInitialized returns true once MarkKanaryDeploymentInitialized has recorded
+// a successful initialization.
+func IsKanaryDeploymentInitialized(status *kanaryv1alpha1.KanaryDeploymentStatus) bool {
+	id := getIndexForConditionType(status, kanaryv1alpha1.InitializingKanaryDeploymentConditionType)
+	return id >= 0 && status.Conditions[id].Status == corev1.ConditionFalse
+}
+
+// MarkKanaryDeploymentInitialized records the outcome of the Initialize lifecycle step. On
+// success (err == nil) it closes out Initializing with reason InitializationComplete. On a
+// non-retriable error (e.g. the target Deployment or HPA can't be found) it also sets Failed
+// so the reconciler stops; on a retriable error it leaves Initializing=True and sets Errored
+// so the reconciler backs off.
+func MarkKanaryDeploymentInitialized(status *kanaryv1alpha1.KanaryDeploymentStatus, now metav1.Time, retriable bool, err error) {
+	if err == nil {
+		setInitializingCondition(status, now, corev1.ConditionFalse, initializationCompleteReason)
+		id := getIndexForConditionType(status, kanaryv1alpha1.InitializingKanaryDeploymentConditionType)
+		status.Conditions[id].Reason = initializationCompleteReason
+		return
+	}
+
+	UpdateKanaryDeploymentStatusCondition(status, now, kanaryv1alpha1.ErroredKanaryDeploymentConditionType, corev1.ConditionTrue, fmt.Sprintf("%v", err))
+	if !retriable {
+		UpdateKanaryDeploymentStatusCondition(status, now, kanaryv1alpha1.FailedKanaryDeploymentConditionType, corev1.ConditionTrue, fmt.Sprintf("%v", err))
+	}
+}
+
+// setInitializingCondition sets the Initializing condition to conditionStatus, appending it
+// even when conditionStatus is False: UpdateKanaryDeploymentStatusCondition only appends a
+// condition that doesn't exist yet when it's True, but a KanaryDeployment can finish
+// initialization (err == nil) before an Initializing condition has ever been recorded.
+func setInitializingCondition(status *kanaryv1alpha1.KanaryDeploymentStatus, now metav1.Time, conditionStatus corev1.ConditionStatus, desc string) {
+	id := getIndexForConditionType(status, kanaryv1alpha1.InitializingKanaryDeploymentConditionType)
+	if id < 0 {
+		status.Conditions = append(status.Conditions, kanaryv1alpha1.KanaryDeploymentCondition{
+			Type:               kanaryv1alpha1.InitializingKanaryDeploymentConditionType,
+			Status:             conditionStatus,
+			LastUpdateTime:     now,
+			LastTransitionTime: now,
+			Message:            desc,
+		})
+		return
+	}
+	UpdateKanaryDeploymentStatusCondition(status, now, kanaryv1alpha1.InitializingKanaryDeploymentConditionType, conditionStatus, desc)
+}
+
 // UpdateKanaryDeploymentStatus used to update the KanaryDeployment.Status if it has changed.
 func UpdateKanaryDeploymentStatus(kclient client.StatusWriter, reqLogger logr.Logger, kd *kanaryv1alpha1.KanaryDeployment, newStatus *kanaryv1alpha1.KanaryDeploymentStatus, result reconcile.Result, err error) (reconcile.Result, error) {
+	now := metav1.Now()
+	switch {
+	case IsKanaryDeploymentPaused(kd):
+		// Freeze the status: only the Paused condition itself is allowed to move while the
+		// canary is paused, so a manual pause can't be clobbered by a reconcile that computed
+		// a newStatus from before the pause was requested.
+		newStatus = kd.Status.DeepCopy()
+		UpdateKanaryDeploymentPauseCondition(newStatus, now, true, getPauseReason(kd))
+	case isFinalising(newStatus):
+		// Teardown is in-flight: leave Succeeded/Failed exactly as the caller computed them
+		// so the init-gate case below can't clear the terminal condition that triggered
+		// finalising in the first place.
+		UpdateKanaryDeploymentPauseCondition(newStatus, now, false, "")
+	case !IsKanaryDeploymentInitialized(newStatus) && !IsKanaryDeploymentFailed(newStatus):
+		// Initialization hasn't completed yet: hold back a premature Running/Succeeded
+		// transition until MarkKanaryDeploymentInitialized closes out Initializing. A
+		// non-retriable init error is still allowed through since it already set Failed.
+		UpdateKanaryDeploymentStatusCondition(newStatus, now, kanaryv1alpha1.SucceededKanaryDeploymentConditionType, corev1.ConditionFalse, "")
+		UpdateKanaryDeploymentPauseCondition(newStatus, now, false, "")
+	default:
+		UpdateKanaryDeploymentPauseCondition(newStatus, now, false, "")
+	}
+
 	updatedStatus := updateStatusWithReport(kd, newStatus)
 	if !apiequality.Semantic.DeepEqual(&kd.Status, updatedStatus) {
 		updatedKd := kd.DeepCopy()
@@ -41,13 +148,21 @@ func UpdateKanaryDeploymentStatus(kclient client.StatusWriter, reqLogger logr.Lo
 	return result, err
 }
 
-// UpdateKanaryDeploymentStatusConditionsFailure used to update the failre StatusConditions
-func UpdateKanaryDeploymentStatusConditionsFailure(status *kanaryv1alpha1.KanaryDeploymentStatus, now metav1.Time, err error) {
-	if err != nil {
-		UpdateKanaryDeploymentStatusCondition(status, now, kanaryv1alpha1.ErroredKanaryDeploymentConditionType, corev1.ConditionTrue, fmt.Sprintf("%v", err))
-	} else {
+// UpdateKanaryDeploymentStatusConditionsFailure used to update the failre StatusConditions.
+// It returns false when err is non-retriable, in which case the Failed condition is also
+// set to True; callers should stop requeuing in that case.
+func UpdateKanaryDeploymentStatusConditionsFailure(status *kanaryv1alpha1.KanaryDeploymentStatus, now metav1.Time, err error) bool {
+	if err == nil {
 		UpdateKanaryDeploymentStatusCondition(status, now, kanaryv1alpha1.ErroredKanaryDeploymentConditionType, corev1.ConditionFalse, "")
+		return true
 	}
+
+	UpdateKanaryDeploymentStatusCondition(status, now, kanaryv1alpha1.ErroredKanaryDeploymentConditionType, corev1.ConditionTrue, fmt.Sprintf("%v", err))
+	if IsNonRetriableError(err) {
+		UpdateKanaryDeploymentStatusCondition(status, now, kanaryv1alpha1.FailedKanaryDeploymentConditionType, corev1.ConditionTrue, fmt.Sprintf("%v", err))
+		return false
+	}
+	return true
 }
 
 // UpdateKanaryDeploymentStatusCondition used to update a specific KanaryDeploymentConditionType
@@ -80,20 +195,48 @@ func NewKanaryDeploymentStatusCondition(conditionType kanaryv1alpha1.KanaryDeplo
 
 // IsKanaryDeploymentFailed returns true if the KanaryDeployment has failed, else returns false
 func IsKanaryDeploymentFailed(status *kanaryv1alpha1.KanaryDeploymentStatus) bool {
-	id := getIndexForConditionType(status, kanaryv1alpha1.FailedKanaryDeploymentConditionType)
-	if id >= 0 && status.Conditions[id].Status == corev1.ConditionTrue {
-		return true
-	}
-	return false
+	return isConditionTrue(status, kanaryv1alpha1.FailedKanaryDeploymentConditionType)
 }
 
 // IsKanaryDeploymentSucceeded returns true if the KanaryDeployment has succeeded, else return false
 func IsKanaryDeploymentSucceeded(status *kanaryv1alpha1.KanaryDeploymentStatus) bool {
-	id := getIndexForConditionType(status, kanaryv1alpha1.SucceededKanaryDeploymentConditionType)
-	if id >= 0 && status.Conditions[id].Status == corev1.ConditionTrue {
-		return true
+	return isConditionTrue(status, kanaryv1alpha1.SucceededKanaryDeploymentConditionType)
+}
+
+func isConditionTrue(status *kanaryv1alpha1.KanaryDeploymentStatus, t kanaryv1alpha1.KanaryDeploymentConditionType) bool {
+	id := getIndexForConditionType(status, t)
+	return id >= 0 && status.Conditions[id].Status == corev1.ConditionTrue
+}
+
+// Annotations an operator can set on a KanaryDeployment to freeze a canary mid-flight
+// without editing the CR.
+const (
+	// PausedAnnotationKey, when set to "true", pauses the canary.
+	PausedAnnotationKey = "kanary.k8s.io/canary-paused"
+	// PausedReasonAnnotationKey carries the operator-supplied reason for the pause.
+	PausedReasonAnnotationKey = "kanary.k8s.io/canary-paused-reason"
+)
+
+// UpdateKanaryDeploymentPauseCondition sets the Paused condition according to paused and reason.
+func UpdateKanaryDeploymentPauseCondition(status *kanaryv1alpha1.KanaryDeploymentStatus, now metav1.Time, paused bool, reason string) {
+	conditionStatus := corev1.ConditionFalse
+	if paused {
+		conditionStatus = corev1.ConditionTrue
 	}
-	return false
+	UpdateKanaryDeploymentStatusCondition(status, now, kanaryv1alpha1.PausedKanaryDeploymentConditionType, conditionStatus, reason)
+}
+
+// IsKanaryDeploymentPaused returns true if kd is paused through the
+// kanary.k8s.io/canary-paused annotation. Spec.Validations.Manual selects the "manual"
+// validation strategy for a validation item (see getValidation) and, unlike the
+// annotation, is not itself a request to freeze the canary: treating it as one would make
+// every KanaryDeployment using manual validation permanently paused.
+func IsKanaryDeploymentPaused(kd *kanaryv1alpha1.KanaryDeployment) bool {
+	return kd.Annotations[PausedAnnotationKey] == "true"
+}
+
+func getPauseReason(kd *kanaryv1alpha1.KanaryDeployment) string {
+	return kd.Annotations[PausedReasonAnnotationKey]
 }
 
 func getIndexForConditionType(status *kanaryv1alpha1.KanaryDeploymentStatus, t kanaryv1alpha1.KanaryDeploymentConditionType) int {
@@ -107,11 +250,47 @@ func getIndexForConditionType(status *kanaryv1alpha1.KanaryDeploymentStatus, t k
 	return idCondition
 }
 
+// defaultProgressDeadlineSeconds is used when KanaryDeployment.Spec.ProgressDeadlineSeconds is nil.
+const defaultProgressDeadlineSeconds = 600
+
+// progressDeadlineExceededReason is the condition Reason used when a kanary deployment
+// is flipped to Failed because it could not make progress within its deadline.
+const progressDeadlineExceededReason = "ProgressDeadlineExceeded"
+
+// IsKanaryDeploymentProgressDeadlineExceeded returns true if the KanaryDeployment has been
+// Progressing for longer than deadline without reaching Succeeded or Failed.
+func IsKanaryDeploymentProgressDeadlineExceeded(status *kanaryv1alpha1.KanaryDeploymentStatus, now metav1.Time, deadline time.Duration) bool {
+	if IsKanaryDeploymentSucceeded(status) || IsKanaryDeploymentFailed(status) {
+		return false
+	}
+	id := getIndexForConditionType(status, kanaryv1alpha1.ProgressingKanaryDeploymentConditionType)
+	if id < 0 || status.Conditions[id].Status != corev1.ConditionTrue {
+		return false
+	}
+	return now.Sub(status.Conditions[id].LastTransitionTime.Time) > deadline
+}
+
+// progressDeadline returns kd.Spec.ProgressDeadlineSeconds, falling back to
+// defaultProgressDeadlineSeconds when it is unset.
+func progressDeadline(kd *kanaryv1alpha1.KanaryDeployment) time.Duration {
+	if kd.Spec.ProgressDeadlineSeconds != nil {
+		return time.Duration(*kd.Spec.ProgressDeadlineSeconds) * time.Second
+	}
+	return defaultProgressDeadlineSeconds * time.Second
+}
+
 func getReportStatus(status *kanaryv1alpha1.KanaryDeploymentStatus) string {
-	if IsKanaryDeploymentSucceeded(status) {
+	switch {
+	case isFinalising(status):
+		return "Finalising"
+	case isConditionTrue(status, kanaryv1alpha1.PausedKanaryDeploymentConditionType):
+		return "Paused"
+	case IsKanaryDeploymentSucceeded(status):
 		return "Succeeded"
-	} else if IsKanaryDeploymentFailed(status) {
+	case IsKanaryDeploymentFailed(status):
 		return "Failed"
+	case !IsKanaryDeploymentInitialized(status):
+		return "Initializing"
 	}
 	return "Running"
 }
@@ -142,16 +321,86 @@ func getScale(kd *kanaryv1alpha1.KanaryDeployment) string {
 	return "hpa"
 }
 
-func getTraffic(kd *kanaryv1alpha1.KanaryDeployment) string {
-	return string(kd.Spec.Traffic.Source)
+// getTraffic returns the traffic source, annotated with the current step weight when kd
+// uses a stepped/weighted rollout (kd.Spec.Traffic.Steps is non-empty).
+func getTraffic(kd *kanaryv1alpha1.KanaryDeployment, status *kanaryv1alpha1.KanaryDeploymentStatus) string {
+	if len(kd.Spec.Traffic.Steps) == 0 {
+		return string(kd.Spec.Traffic.Source)
+	}
+	return fmt.Sprintf("%s(weight=%d)", kd.Spec.Traffic.Source, status.CurrentStepWeight)
+}
+
+// AdvanceKanaryStep moves a stepped/weighted rollout to its next step, cycling through
+// StepUpgrade -> StepTrafficRouting -> StepMetricsAnalysis -> StepReady before moving the
+// index forward. While CurrentStepState is StepPaused it is a no-op, keeping the
+// KanaryStepPaused condition in sync so IsKanaryStepPaused reflects it. CurrentStepWeight is
+// refreshed from kd.Spec.Traffic.Steps so getTraffic can surface it.
+func AdvanceKanaryStep(kd *kanaryv1alpha1.KanaryDeployment, status *kanaryv1alpha1.KanaryDeploymentStatus, now metav1.Time) {
+	if status.CurrentStepState == kanaryv1alpha1.StepPaused {
+		UpdateKanaryDeploymentStatusCondition(status, now, kanaryv1alpha1.KanaryStepPausedConditionType, corev1.ConditionTrue, "")
+		return
+	}
+
+	switch status.CurrentStepState {
+	case kanaryv1alpha1.StepUpgrade:
+		status.CurrentStepState = kanaryv1alpha1.StepTrafficRouting
+	case kanaryv1alpha1.StepTrafficRouting:
+		status.CurrentStepState = kanaryv1alpha1.StepMetricsAnalysis
+	case kanaryv1alpha1.StepMetricsAnalysis:
+		status.CurrentStepState = kanaryv1alpha1.StepReady
+		UpdateKanaryDeploymentStatusCondition(status, now, kanaryv1alpha1.KanaryStepSucceededConditionType, corev1.ConditionTrue, "")
+	default:
+		status.CurrentStepIndex++
+		status.CurrentStepState = kanaryv1alpha1.StepUpgrade
+		UpdateKanaryDeploymentStatusCondition(status, now, kanaryv1alpha1.KanaryStepSucceededConditionType, corev1.ConditionFalse, "")
+	}
+
+	if int(status.CurrentStepIndex) < len(kd.Spec.Traffic.Steps) {
+		status.CurrentStepWeight = kd.Spec.Traffic.Steps[status.CurrentStepIndex].Weight
+	}
+	UpdateKanaryDeploymentStatusCondition(status, now, kanaryv1alpha1.KanaryStepPausedConditionType, corev1.ConditionFalse, "")
+	status.LastStepTransitionTime = now
+}
+
+// IsKanaryStepPaused returns true if the current rollout step is paused.
+func IsKanaryStepPaused(status *kanaryv1alpha1.KanaryDeploymentStatus) bool {
+	id := getIndexForConditionType(status, kanaryv1alpha1.KanaryStepPausedConditionType)
+	return id >= 0 && status.Conditions[id].Status == corev1.ConditionTrue
+}
+
+// SetFinalisingStep records the current step of canary teardown, in the order
+// RestoreTraffic -> ScaleDownCanary -> DeleteCanaryService -> DeleteCanaryDeployment, so the
+// reconciler can resume cleanup after a restart instead of starting over.
+func SetFinalisingStep(status *kanaryv1alpha1.KanaryDeploymentStatus, now metav1.Time, step kanaryv1alpha1.FinalisingStepType) {
+	status.CurrentFinalisingStep = step
+	UpdateKanaryDeploymentStatusCondition(status, now, kanaryv1alpha1.FinalisingKanaryDeploymentConditionType, corev1.ConditionTrue, string(step))
+}
+
+// IsFinalisingComplete returns true once the last teardown step (DeleteCanaryDeployment) has
+// been reached.
+func IsFinalisingComplete(status *kanaryv1alpha1.KanaryDeploymentStatus) bool {
+	return status.CurrentFinalisingStep == kanaryv1alpha1.DeleteCanaryDeploymentFinalisingStep
+}
+
+// isFinalising returns true while a teardown step is in-flight.
+func isFinalising(status *kanaryv1alpha1.KanaryDeploymentStatus) bool {
+	return isConditionTrue(status, kanaryv1alpha1.FinalisingKanaryDeploymentConditionType) && !IsFinalisingComplete(status)
 }
 
 func updateStatusWithReport(kd *kanaryv1alpha1.KanaryDeployment, status *kanaryv1alpha1.KanaryDeploymentStatus) *kanaryv1alpha1.KanaryDeploymentStatus {
+	now := metav1.Now()
+	skipDeadlineCheck := isFinalising(status) || isConditionTrue(status, kanaryv1alpha1.PausedKanaryDeploymentConditionType)
+	if !skipDeadlineCheck && IsKanaryDeploymentProgressDeadlineExceeded(status, now, progressDeadline(kd)) {
+		UpdateKanaryDeploymentStatusCondition(status, now, kanaryv1alpha1.FailedKanaryDeploymentConditionType, corev1.ConditionTrue, progressDeadlineExceededReason)
+		id := getIndexForConditionType(status, kanaryv1alpha1.FailedKanaryDeploymentConditionType)
+		status.Conditions[id].Reason = progressDeadlineExceededReason
+	}
+
 	newReport := kanaryv1alpha1.KanaryDeploymentStatusReport{
-		Status:     getReportStatus(&kd.Status),
+		Status:     getReportStatus(status),
 		Validation: getValidation(kd),
 		Scale:      getScale(kd),
-		Traffic:    getTraffic(kd),
+		Traffic:    getTraffic(kd, status),
 	}
 	if !apiequality.Semantic.DeepEqual(status.Report, newReport) {
 		newStatus := status.DeepCopy()
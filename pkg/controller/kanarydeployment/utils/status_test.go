@@ -0,0 +1,469 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	kanaryv1alpha1 "github.com/amadeusitgroup/kanary/pkg/apis/kanary/v1alpha1"
+)
+
+// fakeStatusWriter is a minimal client.StatusWriter that records the last object it was
+// asked to persist, so tests can assert on the status UpdateKanaryDeploymentStatus writes.
+type fakeStatusWriter struct {
+	lastObj runtime.Object
+}
+
+func (w *fakeStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	w.lastObj = obj
+	return nil
+}
+
+func (w *fakeStatusWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return nil
+}
+
+func TestIsKanaryDeploymentProgressDeadlineExceeded(t *testing.T) {
+	now := metav1.Now()
+	deadline := 10 * time.Minute
+
+	progressingSince := func(d time.Duration) *kanaryv1alpha1.KanaryDeploymentStatus {
+		return &kanaryv1alpha1.KanaryDeploymentStatus{
+			Conditions: []kanaryv1alpha1.KanaryDeploymentCondition{
+				{
+					Type:               kanaryv1alpha1.ProgressingKanaryDeploymentConditionType,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(now.Add(-d)),
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		status *kanaryv1alpha1.KanaryDeploymentStatus
+		want   bool
+	}{
+		{
+			name:   "no Progressing condition",
+			status: &kanaryv1alpha1.KanaryDeploymentStatus{},
+			want:   false,
+		},
+		{
+			name:   "Progressing, within deadline",
+			status: progressingSince(deadline / 2),
+			want:   false,
+		},
+		{
+			name:   "Progressing, past deadline",
+			status: progressingSince(deadline * 2),
+			want:   true,
+		},
+		{
+			name: "already Succeeded",
+			status: &kanaryv1alpha1.KanaryDeploymentStatus{
+				Conditions: append(progressingSince(deadline*2).Conditions, kanaryv1alpha1.KanaryDeploymentCondition{
+					Type:   kanaryv1alpha1.SucceededKanaryDeploymentConditionType,
+					Status: corev1.ConditionTrue,
+				}),
+			},
+			want: false,
+		},
+		{
+			name: "already Failed",
+			status: &kanaryv1alpha1.KanaryDeploymentStatus{
+				Conditions: append(progressingSince(deadline*2).Conditions, kanaryv1alpha1.KanaryDeploymentCondition{
+					Type:   kanaryv1alpha1.FailedKanaryDeploymentConditionType,
+					Status: corev1.ConditionTrue,
+				}),
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsKanaryDeploymentProgressDeadlineExceeded(tt.status, now, deadline); got != tt.want {
+				t.Errorf("IsKanaryDeploymentProgressDeadlineExceeded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgressDeadline(t *testing.T) {
+	custom := int32(120)
+	tests := []struct {
+		name string
+		kd   *kanaryv1alpha1.KanaryDeployment
+		want time.Duration
+	}{
+		{
+			name: "unset falls back to default",
+			kd:   &kanaryv1alpha1.KanaryDeployment{},
+			want: defaultProgressDeadlineSeconds * time.Second,
+		},
+		{
+			name: "uses Spec.ProgressDeadlineSeconds when set",
+			kd: &kanaryv1alpha1.KanaryDeployment{
+				Spec: kanaryv1alpha1.KanaryDeploymentSpec{ProgressDeadlineSeconds: &custom},
+			},
+			want: 120 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := progressDeadline(tt.kd); got != tt.want {
+				t.Errorf("progressDeadline() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdvanceKanaryStep(t *testing.T) {
+	now := metav1.Now()
+	kd := &kanaryv1alpha1.KanaryDeployment{
+		Spec: kanaryv1alpha1.KanaryDeploymentSpec{
+			Traffic: kanaryv1alpha1.KanaryDeploymentSpecTraffic{
+				Steps: []kanaryv1alpha1.KanaryDeploymentSpecTrafficStep{
+					{Weight: 10},
+					{Weight: 50},
+					{Weight: 100},
+				},
+			},
+		},
+	}
+
+	t.Run("cycles through the per-step states before advancing the index", func(t *testing.T) {
+		status := &kanaryv1alpha1.KanaryDeploymentStatus{CurrentStepState: kanaryv1alpha1.StepUpgrade}
+
+		AdvanceKanaryStep(kd, status, now)
+		if status.CurrentStepState != kanaryv1alpha1.StepTrafficRouting {
+			t.Fatalf("got state %v, want StepTrafficRouting", status.CurrentStepState)
+		}
+
+		AdvanceKanaryStep(kd, status, now)
+		if status.CurrentStepState != kanaryv1alpha1.StepMetricsAnalysis {
+			t.Fatalf("got state %v, want StepMetricsAnalysis", status.CurrentStepState)
+		}
+
+		AdvanceKanaryStep(kd, status, now)
+		if status.CurrentStepState != kanaryv1alpha1.StepReady {
+			t.Fatalf("got state %v, want StepReady", status.CurrentStepState)
+		}
+		if !isConditionTrue(status, kanaryv1alpha1.KanaryStepSucceededConditionType) {
+			t.Fatal("expected KanaryStepSucceeded to be True once StepReady is reached")
+		}
+
+		AdvanceKanaryStep(kd, status, now)
+		if status.CurrentStepIndex != 1 || status.CurrentStepState != kanaryv1alpha1.StepUpgrade {
+			t.Fatalf("got index %d state %v, want index 1 state StepUpgrade", status.CurrentStepIndex, status.CurrentStepState)
+		}
+		if status.CurrentStepWeight != 50 {
+			t.Fatalf("got weight %d, want 50 (from Spec.Traffic.Steps[1])", status.CurrentStepWeight)
+		}
+	})
+
+	t.Run("StepPaused is a no-op and keeps KanaryStepPaused True", func(t *testing.T) {
+		status := &kanaryv1alpha1.KanaryDeploymentStatus{
+			CurrentStepState:       kanaryv1alpha1.StepPaused,
+			CurrentStepIndex:       0,
+			LastStepTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+		}
+
+		AdvanceKanaryStep(kd, status, now)
+
+		if status.CurrentStepState != kanaryv1alpha1.StepPaused || status.CurrentStepIndex != 0 {
+			t.Fatalf("paused step must not advance, got index %d state %v", status.CurrentStepIndex, status.CurrentStepState)
+		}
+		if !IsKanaryStepPaused(status) {
+			t.Fatal("expected IsKanaryStepPaused to be true while CurrentStepState is StepPaused")
+		}
+		if status.LastStepTransitionTime.Time.Equal(now.Time) {
+			t.Fatal("LastStepTransitionTime should not be touched by a no-op pause")
+		}
+	})
+}
+
+func TestMarkKanaryDeploymentInitialized(t *testing.T) {
+	now := metav1.Now()
+
+	t.Run("success on a fresh status closes out Initializing", func(t *testing.T) {
+		status := &kanaryv1alpha1.KanaryDeploymentStatus{}
+
+		MarkKanaryDeploymentInitialized(status, now, true, nil)
+
+		if !IsKanaryDeploymentInitialized(status) {
+			t.Fatal("expected IsKanaryDeploymentInitialized to be true after a successful Mark call, even with no prior Initializing condition")
+		}
+	})
+
+	t.Run("retriable error does not complete initialization and sets Errored", func(t *testing.T) {
+		status := &kanaryv1alpha1.KanaryDeploymentStatus{}
+
+		MarkKanaryDeploymentInitialized(status, now, true, errors.New("transient"))
+
+		if IsKanaryDeploymentInitialized(status) {
+			t.Fatal("expected IsKanaryDeploymentInitialized to stay false on a retriable error")
+		}
+		if !isConditionTrue(status, kanaryv1alpha1.ErroredKanaryDeploymentConditionType) {
+			t.Fatal("expected Errored to be set on a retriable error")
+		}
+		if IsKanaryDeploymentFailed(status) {
+			t.Fatal("a retriable error must not set Failed")
+		}
+	})
+
+	t.Run("non-retriable error sets Failed", func(t *testing.T) {
+		status := &kanaryv1alpha1.KanaryDeploymentStatus{}
+
+		MarkKanaryDeploymentInitialized(status, now, false, errors.New("target Deployment missing"))
+
+		if IsKanaryDeploymentInitialized(status) {
+			t.Fatal("expected IsKanaryDeploymentInitialized to stay false on a non-retriable error")
+		}
+		if !IsKanaryDeploymentFailed(status) {
+			t.Fatal("expected Failed to be set on a non-retriable error")
+		}
+	})
+}
+
+func TestIsKanaryDeploymentPaused(t *testing.T) {
+	tests := []struct {
+		name string
+		kd   *kanaryv1alpha1.KanaryDeployment
+		want bool
+	}{
+		{
+			name: "no annotation",
+			kd:   &kanaryv1alpha1.KanaryDeployment{},
+			want: false,
+		},
+		{
+			name: "annotation set to true",
+			kd: &kanaryv1alpha1.KanaryDeployment{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{PausedAnnotationKey: "true"}},
+			},
+			want: true,
+		},
+		{
+			name: "annotation set to an unrecognized value",
+			kd: &kanaryv1alpha1.KanaryDeployment{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{PausedAnnotationKey: "yes"}},
+			},
+			want: false,
+		},
+		{
+			name: "Spec.Validations.Manual set alone must not pause",
+			kd: &kanaryv1alpha1.KanaryDeployment{
+				Spec: kanaryv1alpha1.KanaryDeploymentSpec{
+					Validations: kanaryv1alpha1.KanaryDeploymentSpecValidations{
+						Items: []kanaryv1alpha1.KanaryDeploymentSpecValidationItem{
+							{Manual: &kanaryv1alpha1.ManualValidation{}},
+						},
+					},
+				},
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsKanaryDeploymentPaused(tt.kd); got != tt.want {
+				t.Errorf("IsKanaryDeploymentPaused() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateKanaryDeploymentPauseCondition(t *testing.T) {
+	now := metav1.Now()
+	status := &kanaryv1alpha1.KanaryDeploymentStatus{}
+
+	UpdateKanaryDeploymentPauseCondition(status, now, true, "investigating a regression")
+	if !isConditionTrue(status, kanaryv1alpha1.PausedKanaryDeploymentConditionType) {
+		t.Fatal("expected Paused to be True")
+	}
+
+	UpdateKanaryDeploymentPauseCondition(status, now, false, "")
+	if isConditionTrue(status, kanaryv1alpha1.PausedKanaryDeploymentConditionType) {
+		t.Fatal("expected Paused to be False once the canary is resumed")
+	}
+}
+
+func TestGetPauseReason(t *testing.T) {
+	kd := &kanaryv1alpha1.KanaryDeployment{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{PausedReasonAnnotationKey: "investigating a regression"}},
+	}
+	if got := getPauseReason(kd); got != "investigating a regression" {
+		t.Errorf("getPauseReason() = %q, want %q", got, "investigating a regression")
+	}
+	if got := getPauseReason(&kanaryv1alpha1.KanaryDeployment{}); got != "" {
+		t.Errorf("getPauseReason() = %q, want empty string when annotation is unset", got)
+	}
+}
+
+func TestUpdateKanaryDeploymentStatusPausedPastDeadline(t *testing.T) {
+	now := metav1.Now()
+	deadline := int32(60)
+	kd := &kanaryv1alpha1.KanaryDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{PausedAnnotationKey: "true", PausedReasonAnnotationKey: "investigating"},
+		},
+		Spec: kanaryv1alpha1.KanaryDeploymentSpec{ProgressDeadlineSeconds: &deadline},
+	}
+	kd.Status = kanaryv1alpha1.KanaryDeploymentStatus{
+		Conditions: []kanaryv1alpha1.KanaryDeploymentCondition{
+			{
+				Type:               kanaryv1alpha1.ProgressingKanaryDeploymentConditionType,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+			},
+		},
+	}
+	newStatus := kd.Status.DeepCopy()
+
+	writer := &fakeStatusWriter{}
+	var reqLogger logr.Logger
+
+	if _, err := UpdateKanaryDeploymentStatus(writer, reqLogger, kd, newStatus, reconcile.Result{}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updated, ok := writer.lastObj.(*kanaryv1alpha1.KanaryDeployment)
+	if !ok {
+		t.Fatal("expected the status writer to receive the updated *KanaryDeployment")
+	}
+	if IsKanaryDeploymentFailed(&updated.Status) {
+		t.Fatal("a paused canary with a stale Progressing condition must not be forced to Failed")
+	}
+	if !isConditionTrue(&updated.Status, kanaryv1alpha1.PausedKanaryDeploymentConditionType) {
+		t.Fatal("expected Paused to stay True")
+	}
+}
+
+func TestSetFinalisingStep(t *testing.T) {
+	now := metav1.Now()
+	status := &kanaryv1alpha1.KanaryDeploymentStatus{}
+
+	steps := []kanaryv1alpha1.FinalisingStepType{
+		kanaryv1alpha1.RestoreTrafficFinalisingStep,
+		kanaryv1alpha1.ScaleDownCanaryFinalisingStep,
+		kanaryv1alpha1.DeleteCanaryServiceFinalisingStep,
+		kanaryv1alpha1.DeleteCanaryDeploymentFinalisingStep,
+	}
+
+	for i, step := range steps {
+		SetFinalisingStep(status, now, step)
+		if status.CurrentFinalisingStep != step {
+			t.Fatalf("step %d: got CurrentFinalisingStep %v, want %v", i, status.CurrentFinalisingStep, step)
+		}
+		if !isConditionTrue(status, kanaryv1alpha1.FinalisingKanaryDeploymentConditionType) {
+			t.Fatalf("step %d: expected Finalising to be True", i)
+		}
+		wantComplete := step == kanaryv1alpha1.DeleteCanaryDeploymentFinalisingStep
+		if got := IsFinalisingComplete(status); got != wantComplete {
+			t.Fatalf("step %d: IsFinalisingComplete() = %v, want %v", i, got, wantComplete)
+		}
+		wantFinalising := !wantComplete
+		if got := isFinalising(status); got != wantFinalising {
+			t.Fatalf("step %d: isFinalising() = %v, want %v", i, got, wantFinalising)
+		}
+	}
+}
+
+func TestUpdateKanaryDeploymentStatusFinalisingDoesNotDoubleFail(t *testing.T) {
+	now := metav1.Now()
+	deadline := int32(60)
+	kd := &kanaryv1alpha1.KanaryDeployment{
+		Spec: kanaryv1alpha1.KanaryDeploymentSpec{ProgressDeadlineSeconds: &deadline},
+	}
+	kd.Status = kanaryv1alpha1.KanaryDeploymentStatus{
+		Conditions: []kanaryv1alpha1.KanaryDeploymentCondition{
+			{
+				Type:               kanaryv1alpha1.FailedKanaryDeploymentConditionType,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: now,
+			},
+			{
+				Type:               kanaryv1alpha1.ProgressingKanaryDeploymentConditionType,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+			},
+		},
+	}
+	newStatus := kd.Status.DeepCopy()
+	SetFinalisingStep(newStatus, now, kanaryv1alpha1.RestoreTrafficFinalisingStep)
+
+	writer := &fakeStatusWriter{}
+	var reqLogger logr.Logger
+
+	if _, err := UpdateKanaryDeploymentStatus(writer, reqLogger, kd, newStatus, reconcile.Result{}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updated, ok := writer.lastObj.(*kanaryv1alpha1.KanaryDeployment)
+	if !ok {
+		t.Fatal("expected the status writer to receive the updated *KanaryDeployment")
+	}
+	if !IsKanaryDeploymentFailed(&updated.Status) {
+		t.Fatal("Failed must stay True while tearing down a canary that already failed")
+	}
+	if got := getReportStatus(&updated.Status); got != "Finalising" {
+		t.Fatalf("getReportStatus() = %q, want %q while a teardown step is in-flight", got, "Finalising")
+	}
+}
+
+func TestUpdateKanaryDeploymentStatusConditionsFailure(t *testing.T) {
+	now := metav1.Now()
+
+	t.Run("nil error clears Errored and is retriable", func(t *testing.T) {
+		status := &kanaryv1alpha1.KanaryDeploymentStatus{}
+
+		if retriable := UpdateKanaryDeploymentStatusConditionsFailure(status, now, nil); !retriable {
+			t.Fatal("expected nil error to be reported as retriable")
+		}
+		if isConditionTrue(status, kanaryv1alpha1.ErroredKanaryDeploymentConditionType) {
+			t.Fatal("expected Errored to be False for a nil error")
+		}
+	})
+
+	t.Run("non-retriable error sets Errored and Failed, reports not retriable", func(t *testing.T) {
+		status := &kanaryv1alpha1.KanaryDeploymentStatus{}
+
+		if retriable := UpdateKanaryDeploymentStatusConditionsFailure(status, now, NewNonRetriableError(errors.New("boom"))); retriable {
+			t.Fatal("expected a NonRetriableError to be reported as not retriable")
+		}
+		if !isConditionTrue(status, kanaryv1alpha1.ErroredKanaryDeploymentConditionType) {
+			t.Fatal("expected Errored to be True")
+		}
+		if !IsKanaryDeploymentFailed(status) {
+			t.Fatal("expected Failed to be True")
+		}
+	})
+
+	t.Run("retriable error sets Errored but not Failed", func(t *testing.T) {
+		status := &kanaryv1alpha1.KanaryDeploymentStatus{}
+
+		if retriable := UpdateKanaryDeploymentStatusConditionsFailure(status, now, errors.New("flaky")); !retriable {
+			t.Fatal("expected a plain error to be reported as retriable")
+		}
+		if !isConditionTrue(status, kanaryv1alpha1.ErroredKanaryDeploymentConditionType) {
+			t.Fatal("expected Errored to be True")
+		}
+		if IsKanaryDeploymentFailed(status) {
+			t.Fatal("a retriable error must not set Failed")
+		}
+	})
+}